@@ -3,10 +3,12 @@ package dockergoal
 
 import (
 	"strings"
+	"time"
 
 	"github.com/facebookgo/dockerutil"
 	"github.com/facebookgo/errgroup"
 	"github.com/facebookgo/stackerr"
+	"github.com/golint-fixer/dockerutil/dockergoal/errdefs"
 	"github.com/samalba/dockerclient"
 )
 
@@ -19,6 +21,10 @@ type Container struct {
 	forceRemoveExisting bool
 	checkRunningImage   bool
 	authConfig          *dockerclient.AuthConfig
+	readinessProbe      func(dockerclient.Client, *dockerclient.ContainerInfo) error
+	readinessTimeout    time.Duration
+	readinessInterval   time.Duration
+	driftFields         []string
 }
 
 // ContainerOption configure options for a container.
@@ -100,6 +106,35 @@ func ContainerAuthConfig(ac *dockerclient.AuthConfig) ContainerOption {
 	}
 }
 
+// ContainerReadinessProbe configures a probe Apply uses to decide when a
+// container is ready (e.g. a TCP connect or HTTP GET). Apply calls probe,
+// waiting interval between attempts, until it succeeds or timeout elapses.
+// The vendored dockerclient this package builds against doesn't expose
+// Docker's own HEALTHCHECK status, so a probe is the only way to gate
+// readiness; Apply returns immediately if this option isn't set.
+func ContainerReadinessProbe(
+	probe func(dockerclient.Client, *dockerclient.ContainerInfo) error,
+	timeout, interval time.Duration,
+) ContainerOption {
+	return func(c *Container) error {
+		c.readinessProbe = probe
+		c.readinessTimeout = timeout
+		c.readinessInterval = interval
+		return nil
+	}
+}
+
+// ContainerDriftFields restricts checkRunning (via ContainerCheckRunningImage)
+// to comparing only the given fields, instead of the full set of
+// DriftField* constants. Useful when some fields (e.g. Labels set by an
+// orchestrator) are expected to vary and shouldn't trigger recreation.
+func ContainerDriftFields(fields ...string) ContainerOption {
+	return func(c *Container) error {
+		c.driftFields = fields
+		return nil
+	}
+}
+
 // Apply creates the container, possibly removing it as necessary based on the
 // container options that were set.
 func (c *Container) Apply(docker dockerclient.Client) error {
@@ -108,7 +143,7 @@ func (c *Container) Apply(docker dockerclient.Client) error {
 	// force remove existing
 	if c.forceRemoveExisting {
 		if err := docker.RemoveContainer(ci.Id, true, false); err != nil {
-			return stackerr.Wrap(err)
+			return errdefs.WrapConflict(err)
 		}
 		// we just removed the running container and want to start a new one
 		err = dockerclient.ErrNotFound
@@ -147,7 +182,14 @@ func (c *Container) Apply(docker dockerclient.Client) error {
 	// start the container
 	err = docker.StartContainer(ci.Id, c.hostConfig)
 	if err != nil {
-		return stackerr.Wrap(err)
+		return errdefs.WrapConflict(err)
+	}
+
+	// don't consider the container ready until it passes the readiness probe
+	if c.readinessProbe != nil {
+		if err := c.waitReady(docker, ci.Id); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -165,28 +207,27 @@ func (c *Container) checkRunning(docker dockerclient.Client, current *dockerclie
 		return false, err
 	}
 
-	if current.Image != desiredImageID {
+	drift, err := c.checkDrift(docker, current, desiredImageID)
+	if err != nil {
+		return false, err
+	}
+
+	if len(drift) > 0 {
 		// if we aren't allowed to remove the existing container, consider this a failure
 		if !c.removeExisting {
-			return false, stackerr.Newf(
-				"container %q running with image %q but desired image is %q with id %q",
-				c.name,
-				current.Image,
-				c.containerConfig.Image,
-				desiredImageID,
-			)
+			return false, errdefs.NewConfigDrift(c.name, drift)
 		}
 
 		// otherwise remove it since it isn't want we want
 		if err := docker.RemoveContainer(current.Id, true, false); err != nil {
-			return false, stackerr.Wrap(err)
+			return false, errdefs.WrapConflict(err)
 		}
 
 		// trigger starting a new container
 		return false, nil
 	}
 
-	// we're running the correct image
+	// we're running the desired config
 	return true, nil
 }
 
@@ -201,7 +242,26 @@ func ApplyGraph(docker dockerclient.Client, containers []*Container) error {
 		known[c.name] = struct{}{}
 	}
 
-	// TODO: parallel pull pass?
+	// make sure every link, VolumesFrom and container: NetworkMode dependency
+	// points at a container we actually know about
+	for _, c := range containers {
+		for _, dep := range containerDeps(c) {
+			if _, ok := known[dep.name]; !ok {
+				return errdefs.NewLinkUnknown(c.name, dep.raw)
+			}
+		}
+	}
+
+	// a cycle would otherwise cause the round scheduler below to loop forever
+	if cycle := findCycle(containers); cycle != nil {
+		return stackerr.Newf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+	}
+
+	// pull all the images up front, in parallel, so the round scheduler below
+	// doesn't serialize pulls inside each container's Apply
+	if err := PullAll(docker, containers); err != nil {
+		return err
+	}
 
 	// keep doing rounds of parallel starts until we're all done or error out
 	pending := containers
@@ -212,22 +272,11 @@ func ApplyGraph(docker dockerclient.Client, containers []*Container) error {
 
 	pendingLoop:
 		for _, c := range pending {
-			if c.hostConfig != nil {
-				// TODO: also include c.hostConfig.VolumesFrom
-				for _, link := range c.hostConfig.Links {
-					// only care about the name, not the alias
-					parts := strings.Split(link, ":")
-
-					// make sure the link is known
-					if _, ok := known[parts[0]]; !ok {
-						return stackerr.Newf("%s expects unknown link %s", c.name, link)
-					}
-
-					// we need to wait for a dependency, schedule for the next round
-					if !started[parts[0]] {
-						nextRound = append(nextRound, c)
-						continue pendingLoop
-					}
+			for _, dep := range containerDeps(c) {
+				// we need to wait for a dependency, schedule for the next round
+				if !started[dep.name] {
+					nextRound = append(nextRound, c)
+					continue pendingLoop
 				}
 			}
 
@@ -0,0 +1,263 @@
+package dockergoal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samalba/dockerclient"
+)
+
+// Drift field names usable with ContainerDriftFields to restrict which
+// fields checkRunning compares between the running container and the
+// desired config.
+const (
+	DriftFieldImage         = "image"
+	DriftFieldEnv           = "env"
+	DriftFieldCmd           = "cmd"
+	DriftFieldEntrypoint    = "entrypoint"
+	DriftFieldExposedPorts  = "exposedPorts"
+	DriftFieldPortBindings  = "portBindings"
+	DriftFieldBinds         = "binds"
+	DriftFieldRestartPolicy = "restartPolicy"
+	DriftFieldLabels        = "labels"
+	DriftFieldMemory        = "memory"
+	DriftFieldCPUShares     = "cpuShares"
+)
+
+// allDriftFields is compared when ContainerDriftFields isn't used to
+// restrict checkRunning to a subset.
+var allDriftFields = []string{
+	DriftFieldImage,
+	DriftFieldEnv,
+	DriftFieldCmd,
+	DriftFieldEntrypoint,
+	DriftFieldExposedPorts,
+	DriftFieldPortBindings,
+	DriftFieldBinds,
+	DriftFieldRestartPolicy,
+	DriftFieldLabels,
+	DriftFieldMemory,
+	DriftFieldCPUShares,
+}
+
+// checkDrift compares current against c's desired containerConfig and
+// hostConfig, restricted to c.driftFields when set, and returns a
+// description of every field that differs, keyed by field name. An empty
+// result means the running container already matches the desired config.
+//
+// Env and ExposedPorts aren't compared against desiredConfig directly:
+// Docker's inspect output for a running container merges in whatever the
+// image itself sets via ENV/EXPOSE, so a desiredConfig that only lists
+// overrides would drift against every container built from an image with
+// its own defaults. Instead the image's own defaults are merged with
+// desiredConfig before comparing, the same way Docker merges them when
+// starting the container.
+func (c *Container) checkDrift(docker dockerclient.Client, current *dockerclient.ContainerInfo, desiredImageID string) (map[string]string, error) {
+	fields := c.driftFields
+	if len(fields) == 0 {
+		fields = allDriftFields
+	}
+	want := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		want[f] = true
+	}
+
+	drift := map[string]string{}
+	add := func(field string, desired, actual interface{}) {
+		drift[field] = fmt.Sprintf("desired %v, running %v", desired, actual)
+	}
+
+	desiredConfig := c.containerConfig
+
+	if want[DriftFieldImage] && current.Image != desiredImageID {
+		add(DriftFieldImage, desiredConfig.Image, current.Image)
+	}
+
+	if currentConfig := current.Config; currentConfig != nil {
+		var imageConfig *dockerclient.ContainerConfig
+		if want[DriftFieldEnv] || want[DriftFieldExposedPorts] {
+			imageInfo, err := docker.InspectImage(desiredImageID)
+			if err != nil {
+				return nil, err
+			}
+			imageConfig = imageInfo.Config
+		}
+
+		if want[DriftFieldEnv] {
+			expectedEnv := mergeEnv(imageConfig.Env, desiredConfig.Env)
+			if !equalStrSet(expectedEnv, currentConfig.Env) {
+				add(DriftFieldEnv, expectedEnv, currentConfig.Env)
+			}
+		}
+		if want[DriftFieldCmd] && !equalStrSlice(desiredConfig.Cmd, currentConfig.Cmd) {
+			add(DriftFieldCmd, desiredConfig.Cmd, currentConfig.Cmd)
+		}
+		if want[DriftFieldEntrypoint] && !equalStrSlice(desiredConfig.Entrypoint, currentConfig.Entrypoint) {
+			add(DriftFieldEntrypoint, desiredConfig.Entrypoint, currentConfig.Entrypoint)
+		}
+		if want[DriftFieldExposedPorts] {
+			expectedPorts := mergePortSet(imageConfig.ExposedPorts, desiredConfig.ExposedPorts)
+			if !equalPortSet(expectedPorts, currentConfig.ExposedPorts) {
+				add(DriftFieldExposedPorts, expectedPorts, currentConfig.ExposedPorts)
+			}
+		}
+		if want[DriftFieldLabels] && !equalStrMap(desiredConfig.Labels, currentConfig.Labels) {
+			add(DriftFieldLabels, desiredConfig.Labels, currentConfig.Labels)
+		}
+		if want[DriftFieldMemory] && desiredConfig.Memory != currentConfig.Memory {
+			add(DriftFieldMemory, desiredConfig.Memory, currentConfig.Memory)
+		}
+		if want[DriftFieldCPUShares] && desiredConfig.CpuShares != currentConfig.CpuShares {
+			add(DriftFieldCPUShares, desiredConfig.CpuShares, currentConfig.CpuShares)
+		}
+	}
+
+	if desiredHost := c.hostConfig; desiredHost != nil {
+		if currentHost := current.HostConfig; currentHost != nil {
+			if want[DriftFieldPortBindings] && !equalPortBindings(desiredHost.PortBindings, currentHost.PortBindings) {
+				add(DriftFieldPortBindings, desiredHost.PortBindings, currentHost.PortBindings)
+			}
+			if want[DriftFieldBinds] && !equalStrSlice(desiredHost.Binds, currentHost.Binds) {
+				add(DriftFieldBinds, desiredHost.Binds, currentHost.Binds)
+			}
+			if want[DriftFieldRestartPolicy] && desiredHost.RestartPolicy != currentHost.RestartPolicy {
+				add(DriftFieldRestartPolicy, desiredHost.RestartPolicy, currentHost.RestartPolicy)
+			}
+		}
+	}
+
+	return drift, nil
+}
+
+// envKey returns the "KEY" part of a "KEY=VALUE" environment entry.
+func envKey(kv string) string {
+	if i := strings.IndexByte(kv, '='); i >= 0 {
+		return kv[:i]
+	}
+	return kv
+}
+
+// mergeEnv merges base (e.g. an image's own ENV defaults) with overrides,
+// keeping base's ordering for keys it defines and appending any new keys
+// overrides introduces. A key in overrides replaces the same key in base.
+func mergeEnv(base, overrides []string) []string {
+	value := make(map[string]string, len(base)+len(overrides))
+	order := make([]string, 0, len(base)+len(overrides))
+
+	for _, kv := range base {
+		k := envKey(kv)
+		if _, ok := value[k]; !ok {
+			order = append(order, k)
+		}
+		value[k] = kv
+	}
+	for _, kv := range overrides {
+		k := envKey(kv)
+		if _, ok := value[k]; !ok {
+			order = append(order, k)
+		}
+		value[k] = kv
+	}
+
+	merged := make([]string, len(order))
+	for i, k := range order {
+		merged[i] = value[k]
+	}
+	return merged
+}
+
+// mergePortSet returns the union of base (e.g. an image's own EXPOSE
+// defaults) and overrides.
+func mergePortSet(base, overrides map[string]struct{}) map[string]struct{} {
+	merged := make(map[string]struct{}, len(base)+len(overrides))
+	for k := range base {
+		merged[k] = struct{}{}
+	}
+	for k := range overrides {
+		merged[k] = struct{}{}
+	}
+	return merged
+}
+
+func equalStrSlice(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalStrSet compares a and b as multisets, ignoring order, since Docker
+// doesn't guarantee inspect output preserves the order entries were merged
+// in.
+func equalStrSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[string]int, len(a))
+	for _, s := range a {
+		count[s]++
+	}
+	for _, s := range b {
+		count[s]--
+	}
+	for _, n := range count {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func equalStrMap(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func equalPortSet(a, b map[string]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func equalPortBindings(a, b map[string][]dockerclient.PortBinding) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for port, aBindings := range a {
+		bBindings, ok := b[port]
+		if !ok || !equalPortBindingSlice(aBindings, bBindings) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalPortBindingSlice(a, b []dockerclient.PortBinding) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
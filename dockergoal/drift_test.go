@@ -0,0 +1,62 @@
+package dockergoal
+
+import "testing"
+
+func TestMergeEnv(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "LANG=C"}
+	overrides := []string{"LANG=en_US.UTF-8", "FOO=bar"}
+
+	got := mergeEnv(base, overrides)
+	want := []string{"PATH=/usr/bin", "LANG=en_US.UTF-8", "FOO=bar"}
+
+	if !equalStrSlice(got, want) {
+		t.Fatalf("mergeEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestEqualStrSetIgnoresOrder(t *testing.T) {
+	a := []string{"PATH=/usr/bin", "FOO=bar"}
+	b := []string{"FOO=bar", "PATH=/usr/bin"}
+
+	if !equalStrSet(a, b) {
+		t.Fatalf("equalStrSet(%v, %v) = false, want true", a, b)
+	}
+}
+
+func TestEqualStrSetDetectsDrift(t *testing.T) {
+	a := []string{"PATH=/usr/bin"}
+	b := []string{"PATH=/usr/bin", "FOO=bar"}
+
+	if equalStrSet(a, b) {
+		t.Fatalf("equalStrSet(%v, %v) = true, want false", a, b)
+	}
+}
+
+func TestMergePortSet(t *testing.T) {
+	base := map[string]struct{}{"80/tcp": {}}
+	overrides := map[string]struct{}{"443/tcp": {}}
+
+	got := mergePortSet(base, overrides)
+	want := map[string]struct{}{"80/tcp": {}, "443/tcp": {}}
+
+	if !equalPortSet(got, want) {
+		t.Fatalf("mergePortSet() = %v, want %v", got, want)
+	}
+}
+
+// TestDriftAgainstImageDefaults guards against comparing desiredConfig
+// directly to the running container's Env/ExposedPorts: a container's
+// inspected config always includes whatever the image itself contributes,
+// so the expected set built for comparison must include the image's
+// defaults too, not just what the caller set explicitly.
+func TestDriftAgainstImageDefaults(t *testing.T) {
+	imageEnv := []string{"PATH=/usr/bin"}
+	desiredEnv := []string{"FOO=bar"}
+
+	expected := mergeEnv(imageEnv, desiredEnv)
+	runningEnv := []string{"PATH=/usr/bin", "FOO=bar"}
+
+	if !equalStrSet(expected, runningEnv) {
+		t.Fatalf("expected merged env %v to match running env %v", expected, runningEnv)
+	}
+}
@@ -0,0 +1,184 @@
+// Package errdefs provides typed errors for dockergoal, so callers can branch
+// on error category instead of comparing sentinels or matching strings. This
+// mirrors the move Docker and Nomad made from string comparisons to errdefs.
+package errdefs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/facebookgo/stackerr"
+	"github.com/samalba/dockerclient"
+)
+
+// NotFound indicates the referenced container does not exist.
+type NotFound struct {
+	err error
+}
+
+func (e *NotFound) Error() string {
+	return e.err.Error()
+}
+
+// WrapNotFound wraps err, identifying it as a NotFound error. It returns nil
+// if err is nil.
+func WrapNotFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	return stackerr.Wrap(&NotFound{err: err})
+}
+
+// Conflict indicates an operation could not complete because the container
+// was in a state that conflicted with what was requested, e.g. it couldn't
+// be removed or started.
+type Conflict struct {
+	err error
+}
+
+func (e *Conflict) Error() string {
+	return e.err.Error()
+}
+
+// WrapConflict wraps err, identifying it as a Conflict error. It returns nil
+// if err is nil.
+func WrapConflict(err error) error {
+	if err == nil {
+		return nil
+	}
+	return stackerr.Wrap(&Conflict{err: err})
+}
+
+// ConfigDrift indicates a running container's config no longer matches the
+// desired config, including a bare image-ID mismatch, and removeExisting
+// wasn't set to allow replacing it. Fields maps each drifted field name (one
+// of the dockergoal.DriftField* constants, e.g. dockergoal.DriftFieldImage)
+// to a description of the difference.
+type ConfigDrift struct {
+	Container string
+	Fields    map[string]string
+}
+
+func (e *ConfigDrift) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	descs := make([]string, len(names))
+	for i, name := range names {
+		descs[i] = fmt.Sprintf("%s (%s)", name, e.Fields[name])
+	}
+
+	return fmt.Sprintf(
+		"container %q config drifted: %s",
+		e.Container,
+		strings.Join(descs, ", "),
+	)
+}
+
+// NewConfigDrift returns a ConfigDrift error.
+func NewConfigDrift(container string, fields map[string]string) error {
+	return stackerr.Wrap(&ConfigDrift{Container: container, Fields: fields})
+}
+
+// LinkUnknown indicates a container depends on another container, via a
+// link, VolumesFrom, or container: NetworkMode, that isn't part of the
+// desired set passed to ApplyGraph.
+type LinkUnknown struct {
+	Container, Dependency string
+}
+
+func (e *LinkUnknown) Error() string {
+	return fmt.Sprintf("%s expects unknown dependency %s", e.Container, e.Dependency)
+}
+
+// NewLinkUnknown returns a LinkUnknown error.
+func NewLinkUnknown(container, dependency string) error {
+	return stackerr.Wrap(&LinkUnknown{Container: container, Dependency: dependency})
+}
+
+// NotReady indicates a container did not become healthy/ready within its
+// configured readiness timeout.
+type NotReady struct {
+	Container string
+	Timeout   time.Duration
+}
+
+func (e *NotReady) Error() string {
+	return fmt.Sprintf("container %q did not become ready within %s", e.Container, e.Timeout)
+}
+
+// NewNotReady returns a NotReady error.
+func NewNotReady(container string, timeout time.Duration) error {
+	return stackerr.Wrap(&NotReady{Container: container, Timeout: timeout})
+}
+
+// isType returns true if any error in err's stackerr.Underlying chain
+// satisfies match. stackerr.Error only exposes a single-level Underlying
+// accessor, so walk it by hand rather than relying on chain-walking helpers
+// stackerr doesn't provide.
+func isType(err error, match func(error) bool) bool {
+	for e := err; e != nil; {
+		if match(e) {
+			return true
+		}
+		se, ok := e.(*stackerr.Error)
+		if !ok {
+			return false
+		}
+		e = se.Underlying()
+	}
+	return false
+}
+
+// IsNotFound returns true if err indicates the container doesn't exist.
+func IsNotFound(err error) bool {
+	return isType(err, func(e error) bool {
+		if e == dockerclient.ErrNotFound {
+			return true
+		}
+		_, ok := e.(*NotFound)
+		return ok
+	})
+}
+
+// IsConflict returns true if err indicates the container was in a state that
+// conflicted with the requested operation.
+func IsConflict(err error) bool {
+	return isType(err, func(e error) bool {
+		_, ok := e.(*Conflict)
+		return ok
+	})
+}
+
+// IsConfigDrift returns true if err indicates a running container's config
+// has drifted from the desired config (a bare image-ID mismatch is reported
+// this way too, as drift restricted to dockergoal.DriftFieldImage).
+func IsConfigDrift(err error) bool {
+	return isType(err, func(e error) bool {
+		_, ok := e.(*ConfigDrift)
+		return ok
+	})
+}
+
+// IsLinkUnknown returns true if err indicates a container depends on a name
+// outside the desired set passed to ApplyGraph.
+func IsLinkUnknown(err error) bool {
+	return isType(err, func(e error) bool {
+		_, ok := e.(*LinkUnknown)
+		return ok
+	})
+}
+
+// IsNotReady returns true if err indicates a container didn't become
+// healthy/ready within its configured readiness timeout.
+func IsNotReady(err error) bool {
+	return isType(err, func(e error) bool {
+		_, ok := e.(*NotReady)
+		return ok
+	})
+}
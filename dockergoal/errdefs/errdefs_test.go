@@ -0,0 +1,48 @@
+package errdefs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samalba/dockerclient"
+)
+
+func TestIsNotFoundMatchesDockerclientSentinel(t *testing.T) {
+	err := WrapNotFound(dockerclient.ErrNotFound)
+	if !IsNotFound(err) {
+		t.Fatalf("IsNotFound(%v) = false, want true", err)
+	}
+}
+
+func TestIsNotFoundMatchesWrappedNotFound(t *testing.T) {
+	err := WrapNotFound(errors.New("no such container"))
+	if !IsNotFound(err) {
+		t.Fatalf("IsNotFound(%v) = false, want true", err)
+	}
+}
+
+func TestIsNotFoundDoesNotMatchConflict(t *testing.T) {
+	err := WrapConflict(errors.New("container is restarting"))
+	if IsNotFound(err) {
+		t.Fatalf("IsNotFound(%v) = true, want false", err)
+	}
+	if !IsConflict(err) {
+		t.Fatalf("IsConflict(%v) = false, want true", err)
+	}
+}
+
+func TestIsConfigDriftMatchesOnlyConfigDrift(t *testing.T) {
+	err := NewConfigDrift("web", map[string]string{"image": "desired x, running y"})
+	if !IsConfigDrift(err) {
+		t.Fatalf("IsConfigDrift(%v) = false, want true", err)
+	}
+	if IsNotReady(err) {
+		t.Fatalf("IsNotReady(%v) = true, want false", err)
+	}
+}
+
+func TestWrapNotFoundNilIsNil(t *testing.T) {
+	if err := WrapNotFound(nil); err != nil {
+		t.Fatalf("WrapNotFound(nil) = %v, want nil", err)
+	}
+}
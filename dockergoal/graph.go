@@ -0,0 +1,95 @@
+package dockergoal
+
+import "strings"
+
+// containerDep is a single dependency a container has on another named
+// container, along with the raw text it came from (used for error messages).
+type containerDep struct {
+	name string
+	raw  string
+}
+
+// containerDeps returns the dependencies of c derived from its links,
+// VolumesFrom and NetworkMode, so ApplyGraph can order startup correctly.
+func containerDeps(c *Container) []containerDep {
+	if c.hostConfig == nil {
+		return nil
+	}
+
+	var deps []containerDep
+
+	for _, link := range c.hostConfig.Links {
+		// only care about the name, not the alias
+		name := strings.Split(link, ":")[0]
+		deps = append(deps, containerDep{name: name, raw: link})
+	}
+
+	for _, vf := range c.hostConfig.VolumesFrom {
+		// strip the :ro/:rw access mode suffix, if any
+		name := strings.TrimSuffix(strings.TrimSuffix(vf, ":ro"), ":rw")
+		deps = append(deps, containerDep{name: name, raw: vf})
+	}
+
+	if name := strings.TrimPrefix(c.hostConfig.NetworkMode, "container:"); name != c.hostConfig.NetworkMode {
+		deps = append(deps, containerDep{name: name, raw: c.hostConfig.NetworkMode})
+	}
+
+	return deps
+}
+
+// findCycle returns the names forming a dependency cycle among containers,
+// or nil if the dependency graph is acyclic.
+func findCycle(containers []*Container) []string {
+	byName := make(map[string]*Container, len(containers))
+	for _, c := range containers {
+		byName[c.name] = c
+	}
+
+	const (
+		white = iota
+		gray
+		black
+	)
+
+	color := map[string]int{}
+	var path []string
+	var cycle []string
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		color[name] = gray
+		path = append(path, name)
+
+		if c, ok := byName[name]; ok {
+			for _, dep := range containerDeps(c) {
+				switch color[dep.name] {
+				case gray:
+					for i, n := range path {
+						if n == dep.name {
+							cycle = append(append([]string{}, path[i:]...), dep.name)
+							return true
+						}
+					}
+				case white:
+					if visit(dep.name) {
+						return true
+					}
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		color[name] = black
+		return false
+	}
+
+	for _, c := range containers {
+		if color[c.name] == white {
+			if visit(c.name) {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
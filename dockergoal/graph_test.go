@@ -0,0 +1,67 @@
+package dockergoal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samalba/dockerclient"
+)
+
+func mustContainer(t *testing.T, name string, host *dockerclient.HostConfig) *Container {
+	c, err := NewContainer(ContainerName(name), ContainerHostConfig(host))
+	if err != nil {
+		t.Fatalf("NewContainer(%s): %v", name, err)
+	}
+	return c
+}
+
+func TestFindCycleAcyclic(t *testing.T) {
+	containers := []*Container{
+		mustContainer(t, "web", &dockerclient.HostConfig{VolumesFrom: []string{"data"}}),
+		mustContainer(t, "data", nil),
+	}
+
+	if cycle := findCycle(containers); cycle != nil {
+		t.Fatalf("findCycle() = %v, want nil", cycle)
+	}
+}
+
+func TestFindCycleVolumesFrom(t *testing.T) {
+	containers := []*Container{
+		mustContainer(t, "a", &dockerclient.HostConfig{VolumesFrom: []string{"b:ro"}}),
+		mustContainer(t, "b", &dockerclient.HostConfig{VolumesFrom: []string{"a"}}),
+	}
+
+	cycle := findCycle(containers)
+	if cycle == nil {
+		t.Fatal("findCycle() = nil, want a cycle")
+	}
+}
+
+func TestFindCycleNetworkMode(t *testing.T) {
+	containers := []*Container{
+		mustContainer(t, "a", &dockerclient.HostConfig{NetworkMode: "container:b"}),
+		mustContainer(t, "b", &dockerclient.HostConfig{NetworkMode: "container:a"}),
+	}
+
+	cycle := findCycle(containers)
+	if cycle == nil {
+		t.Fatal("findCycle() = nil, want a cycle")
+	}
+	want := []string{"a", "b", "a"}
+	if !reflect.DeepEqual(cycle, want) {
+		t.Fatalf("findCycle() = %v, want %v", cycle, want)
+	}
+}
+
+func TestFindCycleSelfReference(t *testing.T) {
+	containers := []*Container{
+		mustContainer(t, "a", &dockerclient.HostConfig{VolumesFrom: []string{"a"}}),
+	}
+
+	cycle := findCycle(containers)
+	want := []string{"a", "a"}
+	if !reflect.DeepEqual(cycle, want) {
+		t.Fatalf("findCycle() = %v, want %v", cycle, want)
+	}
+}
@@ -0,0 +1,99 @@
+package dockergoal
+
+import (
+	"strings"
+
+	"github.com/facebookgo/errgroup"
+	"github.com/facebookgo/stackerr"
+	"github.com/samalba/dockerclient"
+)
+
+// defaultPullConcurrency is the number of images pulled at the same time by
+// PullAll when PullConcurrency isn't specified.
+const defaultPullConcurrency = 4
+
+// PullOption configures the behavior of PullAll.
+type PullOption func(p *pullConfig) error
+
+type pullConfig struct {
+	concurrency int
+}
+
+// PullConcurrency limits how many images PullAll pulls at the same time.
+func PullConcurrency(n int) PullOption {
+	return func(p *pullConfig) error {
+		p.concurrency = n
+		return nil
+	}
+}
+
+// PullAll concurrently pulls every unique image referenced by containers,
+// deduplicated by image name, honoring each container's authConfig. It's
+// meant to be called before the containers are started so that hosts with
+// many containers sharing base images don't pay the pull cost serially.
+func PullAll(docker dockerclient.Client, containers []*Container, opts ...PullOption) error {
+	p := pullConfig{concurrency: defaultPullConcurrency}
+	for _, o := range opts {
+		if err := o(&p); err != nil {
+			return err
+		}
+	}
+	if p.concurrency <= 0 {
+		p.concurrency = defaultPullConcurrency
+	}
+
+	type image struct {
+		name string
+		auth *dockerclient.AuthConfig
+	}
+
+	images := map[string]image{}
+	for _, c := range containers {
+		if c.containerConfig == nil || c.containerConfig.Image == "" {
+			continue
+		}
+		ref := normalizeImageRef(c.containerConfig.Image)
+		if _, ok := images[ref]; ok {
+			continue
+		}
+		images[ref] = image{
+			name: c.containerConfig.Image,
+			auth: c.authConfig,
+		}
+	}
+
+	sem := make(chan struct{}, p.concurrency)
+	var eg errgroup.Group
+	for _, img := range images {
+		sem <- struct{}{}
+		eg.Add(1)
+		go func(img image) {
+			defer eg.Done()
+			defer func() { <-sem }()
+			if err := docker.PullImage(img.name, img.auth); err != nil {
+				eg.Error(stackerr.Wrap(err))
+			}
+		}(img)
+	}
+
+	return eg.Wait()
+}
+
+// normalizeImageRef resolves ref to a repo:tag form so that references that
+// differ only in whether they spell out the implicit default tag (e.g.
+// "nginx" and "nginx:latest") dedupe to the same image. Digest references
+// (name@sha256:...) are already fully qualified and returned unchanged.
+func normalizeImageRef(ref string) string {
+	if strings.Contains(ref, "@") {
+		return ref
+	}
+
+	// only look for a tag separator after the last slash, so a registry
+	// port (host:5000/repo) isn't mistaken for a tag
+	slash := strings.LastIndex(ref, "/")
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		return ref
+	}
+
+	return ref + ":latest"
+}
@@ -0,0 +1,24 @@
+package dockergoal
+
+import "testing"
+
+func TestNormalizeImageRef(t *testing.T) {
+	cases := []struct {
+		ref  string
+		want string
+	}{
+		{"nginx", "nginx:latest"},
+		{"nginx:latest", "nginx:latest"},
+		{"nginx:1.21", "nginx:1.21"},
+		{"library/nginx", "library/nginx:latest"},
+		{"localhost:5000/nginx", "localhost:5000/nginx:latest"},
+		{"localhost:5000/nginx:1.21", "localhost:5000/nginx:1.21"},
+		{"nginx@sha256:abcd", "nginx@sha256:abcd"},
+	}
+
+	for _, tc := range cases {
+		if got := normalizeImageRef(tc.ref); got != tc.want {
+			t.Errorf("normalizeImageRef(%q) = %q, want %q", tc.ref, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package dockergoal
+
+import (
+	"time"
+
+	"github.com/facebookgo/stackerr"
+	"github.com/golint-fixer/dockerutil/dockergoal/errdefs"
+	"github.com/samalba/dockerclient"
+)
+
+// defaultReadinessTimeout and defaultReadinessInterval are used when
+// ContainerReadinessProbe isn't given an explicit timeout/interval.
+const (
+	defaultReadinessTimeout  = 60 * time.Second
+	defaultReadinessInterval = time.Second
+)
+
+// waitReady blocks until c.readinessProbe succeeds, polling every
+// c.readinessInterval. It gives up and returns an errdefs.NotReady error
+// once c.readinessTimeout elapses. Only called when c.readinessProbe is
+// set; Docker's own HEALTHCHECK status isn't usable here since the vendored
+// dockerclient.State this package builds against doesn't expose it.
+func (c *Container) waitReady(docker dockerclient.Client, id string) error {
+	timeout := c.readinessTimeout
+	if timeout <= 0 {
+		timeout = defaultReadinessTimeout
+	}
+	interval := c.readinessInterval
+	if interval <= 0 {
+		interval = defaultReadinessInterval
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ci, err := docker.InspectContainer(id)
+		if err != nil {
+			return stackerr.Wrap(err)
+		}
+
+		if err := c.readinessProbe(docker, ci); err == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errdefs.NewNotReady(c.name, timeout)
+		}
+
+		time.Sleep(interval)
+	}
+}
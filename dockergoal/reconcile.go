@@ -0,0 +1,188 @@
+package dockergoal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/facebookgo/stackerr"
+	"github.com/golint-fixer/dockerutil/dockergoal/errdefs"
+	"github.com/samalba/dockerclient"
+)
+
+// ownerLabel is the label Reconcile uses to identify the containers it owns.
+const ownerLabel = "com.dockergoal.owner"
+
+// defaultStopTimeout is how long Reconcile waits for a stale container to
+// stop gracefully before removing it.
+const defaultStopTimeout = 10
+
+// ReconcileOption configures the behavior of Reconcile.
+type ReconcileOption func(r *reconcileConfig) error
+
+type reconcileConfig struct {
+	owner    string
+	dryRun   bool
+	preserve map[string]struct{}
+}
+
+// ReconcileOwner sets the owner used to scope which existing containers
+// Reconcile considers: only containers labeled com.dockergoal.owner=<owner>
+// are candidates for pruning. Required.
+func ReconcileOwner(owner string) ReconcileOption {
+	return func(r *reconcileConfig) error {
+		r.owner = owner
+		return nil
+	}
+}
+
+// ReconcileDryRun makes Reconcile compute and return the diff without
+// creating, updating or removing any containers.
+func ReconcileDryRun(r *reconcileConfig) ReconcileOption {
+	return func(r *reconcileConfig) error {
+		r.dryRun = true
+		return nil
+	}
+}
+
+// ReconcilePreserve exempts the given container names from removal, even if
+// they're labeled with the owner and aren't part of the desired set.
+func ReconcilePreserve(names ...string) ReconcileOption {
+	return func(r *reconcileConfig) error {
+		if r.preserve == nil {
+			r.preserve = make(map[string]struct{}, len(names))
+		}
+		for _, name := range names {
+			r.preserve[name] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// ReconcileDiff describes the changes Reconcile made, or would make under
+// ReconcileDryRun. Update lists every desired container that already exists
+// with the owner label; ApplyGraph may leave some of them unchanged.
+type ReconcileDiff struct {
+	Create []string
+	Update []string
+	Remove []string
+}
+
+// Reconcile treats desired as the authoritative set of containers for the
+// owner set via ReconcileOwner. It applies desired via ApplyGraph, then stops
+// and removes any container labeled with the same owner that isn't part of
+// desired, so containers from earlier applies don't linger as orphans.
+//
+// Callers are responsible for labeling each desired container's
+// containerConfig with com.dockergoal.owner=<owner> themselves, so it's
+// recognized as part of this owner's set on the next Reconcile.
+func Reconcile(docker dockerclient.Client, desired []*Container, opts ...ReconcileOption) (*ReconcileDiff, error) {
+	var r reconcileConfig
+	for _, o := range opts {
+		if err := o(&r); err != nil {
+			return nil, err
+		}
+	}
+	if r.owner == "" {
+		return nil, stackerr.New("dockergoal: Reconcile requires ReconcileOwner")
+	}
+
+	existing, err := ownedContainers(docker, r.owner)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := computeReconcileDiff(desired, existing, r.preserve)
+
+	if r.dryRun {
+		return diff, nil
+	}
+
+	if err := ApplyGraph(docker, desired); err != nil {
+		return diff, err
+	}
+
+	for _, name := range diff.Remove {
+		// a container already gone (e.g. a concurrent Reconcile beat us to
+		// it) isn't a pruning failure, just a no-op; keep going so one
+		// missing container doesn't leave the rest of diff.Remove untouched
+		if err := stopAndRemove(docker, name); err != nil && !errdefs.IsNotFound(err) {
+			return diff, err
+		}
+	}
+
+	return diff, nil
+}
+
+// computeReconcileDiff compares desired against existing (the containers
+// currently labeled with the reconcile owner, keyed by name) and classifies
+// each name as a create, update or removal, skipping any name in preserve.
+func computeReconcileDiff(
+	desired []*Container,
+	existing map[string]*dockerclient.Container,
+	preserve map[string]struct{},
+) *ReconcileDiff {
+	desiredNames := make(map[string]struct{}, len(desired))
+	diff := &ReconcileDiff{}
+	for _, c := range desired {
+		desiredNames[c.name] = struct{}{}
+		if _, ok := existing[c.name]; ok {
+			diff.Update = append(diff.Update, c.name)
+		} else {
+			diff.Create = append(diff.Create, c.name)
+		}
+	}
+
+	for name := range existing {
+		if _, ok := desiredNames[name]; ok {
+			continue
+		}
+		if _, ok := preserve[name]; ok {
+			continue
+		}
+		diff.Remove = append(diff.Remove, name)
+	}
+
+	return diff
+}
+
+// ownedContainers returns the containers labeled com.dockergoal.owner=owner,
+// keyed by name.
+func ownedContainers(docker dockerclient.Client, owner string) (map[string]*dockerclient.Container, error) {
+	filters := fmt.Sprintf(`{"label":["%s=%s"]}`, ownerLabel, owner)
+	containers, err := docker.ListContainers(true, false, filters)
+	if err != nil {
+		return nil, stackerr.Wrap(err)
+	}
+
+	byName := make(map[string]*dockerclient.Container, len(containers))
+	for i := range containers {
+		c := &containers[i]
+		if len(c.Names) == 0 {
+			continue
+		}
+		// names are reported with a leading slash
+		byName[strings.TrimPrefix(c.Names[0], "/")] = c
+	}
+	return byName, nil
+}
+
+// stopAndRemove stops and removes the named container.
+func stopAndRemove(docker dockerclient.Client, name string) error {
+	ci, err := docker.InspectContainer(name)
+	if err != nil {
+		if err == dockerclient.ErrNotFound {
+			return errdefs.WrapNotFound(err)
+		}
+		return errdefs.WrapConflict(err)
+	}
+
+	if err := docker.StopContainer(ci.Id, defaultStopTimeout); err != nil {
+		return errdefs.WrapConflict(err)
+	}
+
+	if err := docker.RemoveContainer(ci.Id, true, false); err != nil {
+		return errdefs.WrapConflict(err)
+	}
+
+	return nil
+}
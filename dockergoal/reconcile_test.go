@@ -0,0 +1,46 @@
+package dockergoal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samalba/dockerclient"
+)
+
+func TestComputeReconcileDiff(t *testing.T) {
+	desired := []*Container{
+		mustContainer(t, "web", nil),
+		mustContainer(t, "new", nil),
+	}
+	existing := map[string]*dockerclient.Container{
+		"web":   {},
+		"stale": {},
+		"kept":  {},
+	}
+	preserve := map[string]struct{}{"kept": {}}
+
+	diff := computeReconcileDiff(desired, existing, preserve)
+
+	if !reflect.DeepEqual(diff.Create, []string{"new"}) {
+		t.Errorf("Create = %v, want [new]", diff.Create)
+	}
+	if !reflect.DeepEqual(diff.Update, []string{"web"}) {
+		t.Errorf("Update = %v, want [web]", diff.Update)
+	}
+	if !reflect.DeepEqual(diff.Remove, []string{"stale"}) {
+		t.Errorf("Remove = %v, want [stale]", diff.Remove)
+	}
+}
+
+func TestComputeReconcileDiffNoExisting(t *testing.T) {
+	desired := []*Container{mustContainer(t, "web", nil)}
+
+	diff := computeReconcileDiff(desired, map[string]*dockerclient.Container{}, nil)
+
+	if !reflect.DeepEqual(diff.Create, []string{"web"}) {
+		t.Errorf("Create = %v, want [web]", diff.Create)
+	}
+	if len(diff.Update) != 0 || len(diff.Remove) != 0 {
+		t.Errorf("Update/Remove = %v/%v, want empty", diff.Update, diff.Remove)
+	}
+}